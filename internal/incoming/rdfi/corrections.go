@@ -59,8 +59,22 @@ func (pc *correctionProcessor) Type() string {
 	return "correction"
 }
 
+// isCorrectionFile reports whether file actually contains NOC/COR data worth
+// handing to the correction processor, rather than just being any file that
+// happened to come down the RDFI pipeline.
 func isCorrectionFile(file File) bool {
-	return len(file.ACHFile.NotificationOfChange) >= 0
+	if file.ACHFile == nil || len(file.ACHFile.NotificationOfChange) == 0 {
+		return false
+	}
+	for i := range file.ACHFile.NotificationOfChange {
+		entries := file.ACHFile.NotificationOfChange[i].GetEntries()
+		for j := range entries {
+			if entries[j].Addenda98 != nil {
+				return true
+			}
+		}
+	}
+	return false
 }
 
 func (pc *correctionProcessor) Handle(file File) error {
@@ -85,27 +99,50 @@ func (pc *correctionProcessor) Handle(file File) error {
 
 	for i := range file.ACHFile.NotificationOfChange {
 		entries := file.ACHFile.NotificationOfChange[i].GetEntries()
-		msg.Corrections = append(msg.Corrections, models.Batch{
-			Header:  file.ACHFile.NotificationOfChange[i].GetHeader(),
-			Entries: entries,
-		})
 
+		// Keep only change codes the operator has subscribed to. entries is
+		// the batch's own slice (and file.ACHFile is the very pointer we
+		// send as msg.File), so kept must not share its backing array --
+		// slicing with a zero capacity forces the first append to allocate
+		// a fresh array instead of overwriting entries in place. We still
+		// record correctionCodesProcessed for every code we see so
+		// operators retain visibility into filtered-out codes.
+		kept := entries[len(entries):len(entries):len(entries)]
 		for j := range entries {
 			if entries[j].Addenda98 == nil {
 				continue
 			}
 			changeCode := entries[j].Addenda98.ChangeCodeField()
+
 			correctionCodesProcessed.With(
 				"origin", file.ACHFile.Header.ImmediateOrigin,
 				"destination", file.ACHFile.Header.ImmediateDestination,
 				"code", changeCode.Code,
 			).Add(1)
 
+			if !pc.cfg.Allowed(changeCode.Code) {
+				pc.logger.With(log.Fields{
+					"origin":      log.String(file.ACHFile.Header.ImmediateOrigin),
+					"destination": log.String(file.ACHFile.Header.ImmediateDestination),
+				}).Log(fmt.Sprintf("rdfi: filtering correction batch %d entry %d code %s", i, j, changeCode.Code))
+				continue
+			}
+
 			pc.logger.With(log.Fields{
 				"origin":      log.String(file.ACHFile.Header.ImmediateOrigin),
 				"destination": log.String(file.ACHFile.Header.ImmediateDestination),
 			}).Log(fmt.Sprintf("rdfi: correction batch %d entry %d code %s", i, j, changeCode.Code))
+
+			kept = append(kept, entries[j])
 		}
+		if len(kept) == 0 {
+			continue
+		}
+
+		msg.Corrections = append(msg.Corrections, models.Batch{
+			Header:  file.ACHFile.NotificationOfChange[i].GetHeader(),
+			Entries: kept,
+		})
 	}
 	pc.sendEvent(msg)
 	return nil
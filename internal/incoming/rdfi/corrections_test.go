@@ -0,0 +1,156 @@
+// Licensed to The Moov Authors under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. The Moov Authors licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package rdfi
+
+import (
+	"testing"
+
+	"github.com/moov-io/ach"
+	"github.com/moov-io/achgateway/internal/service"
+	"github.com/moov-io/achgateway/pkg/models"
+	"github.com/moov-io/base/log"
+
+	stdprometheus "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/dto"
+)
+
+func TestIsCorrectionFile(t *testing.T) {
+	if isCorrectionFile(File{}) {
+		t.Error("expected empty File to not be a correction file")
+	}
+
+	achFile := &ach.File{}
+	if isCorrectionFile(File{ACHFile: achFile}) {
+		t.Error("expected File with no NotificationOfChange batches to not be a correction file")
+	}
+
+	noc := ach.NewBatchCOR(ach.NewBatchHeader())
+	noc.AddEntry(&ach.EntryDetail{}) // entry with no Addenda98
+	achFile.NotificationOfChange = append(achFile.NotificationOfChange, noc)
+	if isCorrectionFile(File{ACHFile: achFile}) {
+		t.Error("expected NOC batch without an Addenda98 to not be a correction file")
+	}
+
+	noc.GetEntries()[0].Addenda98 = &ach.Addenda98{ChangeCode: "C01"}
+	if !isCorrectionFile(File{ACHFile: achFile}) {
+		t.Error("expected NOC batch with an Addenda98 to be a correction file")
+	}
+}
+
+type capturingEmitter struct {
+	events []interface{}
+}
+
+func (e *capturingEmitter) Send(event models.Event) error {
+	e.events = append(e.events, event.Event)
+	return nil
+}
+
+func TestCorrectionProcessor__Handle(t *testing.T) {
+	bh := ach.NewBatchHeader()
+	noc := ach.NewBatchCOR(bh)
+
+	allowed := &ach.EntryDetail{Addenda98: &ach.Addenda98{ChangeCode: "C01"}}
+	denied := &ach.EntryDetail{Addenda98: &ach.Addenda98{ChangeCode: "C02"}}
+	noc.AddEntry(allowed)
+	noc.AddEntry(denied)
+
+	achFile := &ach.File{
+		Header:               ach.FileHeader{ImmediateOrigin: "origin", ImmediateDestination: "dest"},
+		NotificationOfChange: []ach.Batcher{noc},
+	}
+	originalEntries := append([]*ach.EntryDetail{}, noc.GetEntries()...)
+
+	emitter := &capturingEmitter{}
+	pc := &correctionProcessor{
+		logger: log.NewDefaultLogger(),
+		svc:    emitter,
+		cfg:    service.ODFICorrections{Enabled: true, ChangeCodeAllow: []string{"C01"}},
+	}
+
+	before := counterValue(t, "origin", "dest", "C01")
+	beforeDenied := counterValue(t, "origin", "dest", "C02")
+
+	if err := pc.Handle(File{ACHFile: achFile, Filepath: "corrections.ach"}); err != nil {
+		t.Fatalf("Handle returned an error: %v", err)
+	}
+
+	if got := counterValue(t, "origin", "dest", "C01") - before; got != 1 {
+		t.Errorf("expected correctionCodesProcessed to record C01 once, got delta %v", got)
+	}
+	if got := counterValue(t, "origin", "dest", "C02") - beforeDenied; got != 1 {
+		t.Errorf("expected correctionCodesProcessed to record C02 once even though it was filtered, got delta %v", got)
+	}
+
+	if len(emitter.events) != 1 {
+		t.Fatalf("expected exactly one event to be sent, got %d", len(emitter.events))
+	}
+	msg, ok := emitter.events[0].(models.CorrectionFile)
+	if !ok {
+		t.Fatalf("expected a models.CorrectionFile event, got %T", emitter.events[0])
+	}
+	if len(msg.Corrections) != 1 || len(msg.Corrections[0].Entries) != 1 {
+		t.Fatalf("expected exactly one kept batch with one entry, got %#v", msg.Corrections)
+	}
+	if msg.Corrections[0].Entries[0] != allowed {
+		t.Error("expected the kept entry to be the allowed change code")
+	}
+
+	// Regression guard: filtering into a fresh slice must not touch the
+	// original batch's backing array, which is also the *ach.File we just
+	// reported as processed.
+	if got := noc.GetEntries(); len(got) != 2 || got[0] != originalEntries[0] || got[1] != originalEntries[1] {
+		t.Errorf("expected the original batch entries to be untouched, got %#v", got)
+	}
+}
+
+// counterValue reads the current value of correctionCodesProcessed for the
+// given origin/destination/code labels directly off the default Prometheus
+// registry, since go-kit's Counter doesn't expose its underlying CounterVec.
+func counterValue(t *testing.T, origin, destination, code string) float64 {
+	t.Helper()
+
+	families, err := stdprometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatalf("gathering metrics: %v", err)
+	}
+	want := map[string]string{"origin": origin, "destination": destination, "code": code}
+	for _, family := range families {
+		if family.GetName() != "correction_codes_processed" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			if labelsMatch(metric.GetLabel(), want) {
+				return metric.GetCounter().GetValue()
+			}
+		}
+	}
+	return 0
+}
+
+func labelsMatch(labels []*dto.LabelPair, want map[string]string) bool {
+	if len(labels) != len(want) {
+		return false
+	}
+	for _, l := range labels {
+		if want[l.GetName()] != l.GetValue() {
+			return false
+		}
+	}
+	return true
+}
@@ -19,8 +19,10 @@ package rdfi
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
 	"time"
 
 	"github.com/moov-io/achgateway/internal/alerting"
@@ -29,8 +31,44 @@ import (
 	"github.com/moov-io/achgateway/internal/upload"
 	"github.com/moov-io/base/admin"
 	"github.com/moov-io/base/log"
+
+	"github.com/gorilla/mux"
 )
 
+// triggerInboundTimeout bounds how long an admin-triggered inbound request
+// will wait for the scheduler to pick it up and finish a tick.
+const triggerInboundTimeout = 5 * time.Minute
+
+// manuallyTriggeredInbound is pushed onto PeriodicScheduler.inboundTrigger to
+// ask the scheduler to run an out-of-band inbound tick. An empty shardName
+// means "every shard configured for this scheduler".
+type manuallyTriggeredInbound struct {
+	shardName string
+	C         chan manualInboundResponse
+}
+
+// manualInboundResponse is the result delivered back to whoever submitted a
+// manuallyTriggeredInbound.
+type manualInboundResponse struct {
+	Result *InboundTriggerResult
+	Err    error
+}
+
+// ShardTriggerResult records what happened when a single shard was asked to
+// process its inbound files.
+type ShardTriggerResult struct {
+	Shard   string `json:"shard"`
+	Ran     bool   `json:"ran"`
+	Skipped string `json:"skipped,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// InboundTriggerResult is the JSON body returned by the trigger-inbound admin
+// routes, summarizing what happened across every shard that was asked to run.
+type InboundTriggerResult struct {
+	Shards []ShardTriggerResult `json:"shards"`
+}
+
 type Scheduler interface {
 	Start() error
 	Shutdown()
@@ -101,11 +139,12 @@ func (s *PeriodicScheduler) Start() error {
 		select {
 		case <-s.ticker.C:
 			// Process each Organization we have an upload agent for
-			s.tickAll()
+			s.tickAll("")
 
 		case waiter := <-s.inboundTrigger:
-			// Process each Organization we have an upload agent for
-			waiter.C <- s.tickAll()
+			// Process the requested shard(s), or every shard if none was given
+			result, err := s.tickAll(waiter.shardName)
+			waiter.C <- manualInboundResponse{Result: result, Err: err}
 
 		case <-s.shutdown.Done():
 			s.logger.Log("scheduler shutdown")
@@ -114,30 +153,56 @@ func (s *PeriodicScheduler) Start() error {
 	}
 }
 
-func (s *PeriodicScheduler) tickAll() error {
-	for _, shardName := range s.odfi.ShardNames {
-		shard := s.sharding.Find(shardName)
+// errUnknownShard is returned by tickAll when shardName was given but isn't
+// one of s.odfi.ShardNames, so callers (the admin HTTP handler) can tell a
+// typo apart from every other per-shard failure, which are instead recorded
+// on the returned InboundTriggerResult.
+var errUnknownShard = errors.New("rdfi: unknown shard")
+
+// tickAll runs a tick for every configured shard, or just shardName when it's
+// non-empty. Aside from an unrecognized shardName, it never returns an error
+// itself -- per-shard failures are recorded on the returned
+// InboundTriggerResult and alerted on.
+func (s *PeriodicScheduler) tickAll(shardName string) (*InboundTriggerResult, error) {
+	if shardName != "" && !s.hasShard(shardName) {
+		return nil, fmt.Errorf("%w: %s", errUnknownShard, shardName)
+	}
+
+	result := &InboundTriggerResult{}
+
+	for _, name := range s.odfi.ShardNames {
+		if shardName != "" && name != shardName {
+			continue
+		}
+
+		shardResult := ShardTriggerResult{Shard: name}
+
+		shard := s.sharding.Find(name)
 		if shard == nil {
-			s.logger.Error().Logf("unable to find shard=%s", shardName)
+			shardResult.Error = fmt.Sprintf("unable to find shard=%s", name)
+			s.logger.Error().Log(shardResult.Error)
+			result.Shards = append(result.Shards, shardResult)
 			continue
 		}
 
 		logger := s.logger.With(log.Fields{
-			"shard": log.String(shardName),
+			"shard": log.String(name),
 		})
 
 		// Attempt to acquire leadership prior to processing
-		leaderKey := fmt.Sprintf("achgateway/rdfi/%s", shardName)
+		leaderKey := fmt.Sprintf("achgateway/rdfi/%s", name)
 		s.logger.Logf("attempting to acquire ODFI leadership for %s", leaderKey)
 
 		// Acquire leadership for this shard
 		err := consul.AcquireLock(logger, s.consul, leaderKey)
 		if err != nil {
+			shardResult.Skipped = fmt.Sprintf("missing consul leadership: %v", err)
 			logger.Info().Logf("skipping ODFI processing: %v", err)
 		} else {
 			s.logger.Info().Logf("starting rdfi periodic processing for %s", shard.Name)
-			err := s.tick(shard)
-			if err != nil {
+			shardResult.Ran = true
+			if err := s.tick(shard); err != nil {
+				shardResult.Error = err.Error()
 				// Push this alert outside achgateway
 				s.alertOnError(err)
 				s.logger.Warn().Logf("error with rdfi periodic processing: %v", err)
@@ -145,8 +210,18 @@ func (s *PeriodicScheduler) tickAll() error {
 				s.logger.Info().Logf("finished rdfi periodic processing for %s", shard.Name)
 			}
 		}
+		result.Shards = append(result.Shards, shardResult)
+	}
+	return result, nil
+}
+
+func (s *PeriodicScheduler) hasShard(name string) bool {
+	for _, n := range s.odfi.ShardNames {
+		if n == name {
+			return true
+		}
 	}
-	return nil
+	return false
 }
 
 func (s *PeriodicScheduler) tick(shard *service.Shard) error {
@@ -190,6 +265,66 @@ func (s *PeriodicScheduler) tick(shard *service.Shard) error {
 	return dl.deleteEmptyDirs(agent)
 }
 
+// RegisterRoutes exposes admin endpoints that let an operator trigger an
+// inbound tick on demand instead of waiting for the next ticker fire.
+//
+//	POST /trigger-inbound              -- every configured shard
+//	POST /trigger-inbound/{shardName}  -- a single shard
+func (s *PeriodicScheduler) RegisterRoutes(svc *admin.Server) {
+	svc.AddHandler("/trigger-inbound", s.triggerInboundHandler)
+	svc.AddHandler("/trigger-inbound/{shardName}", s.triggerInboundHandler)
+}
+
+func (s *PeriodicScheduler) triggerInboundHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	shardName := mux.Vars(r)["shardName"]
+
+	if s.shutdown.Err() != nil {
+		http.Error(w, "scheduler is shutting down", http.StatusServiceUnavailable)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), triggerInboundTimeout)
+	defer cancel()
+
+	waiter := manuallyTriggeredInbound{
+		shardName: shardName,
+		C:         make(chan manualInboundResponse, 1),
+	}
+
+	select {
+	case s.inboundTrigger <- waiter:
+	case <-ctx.Done():
+		http.Error(w, "timed out submitting trigger-inbound request", http.StatusGatewayTimeout)
+		return
+	case <-s.shutdown.Done():
+		http.Error(w, "scheduler is shutting down", http.StatusServiceUnavailable)
+		return
+	}
+
+	select {
+	case resp := <-waiter.C:
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		switch {
+		case errors.Is(resp.Err, errUnknownShard):
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{"error": resp.Err.Error()})
+			return
+		case resp.Err != nil:
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+		json.NewEncoder(w).Encode(resp.Result)
+
+	case <-ctx.Done():
+		http.Error(w, "timed out waiting for trigger-inbound to complete", http.StatusGatewayTimeout)
+	}
+}
+
 func (s *PeriodicScheduler) alertOnError(err error) {
 	if s == nil {
 		return
@@ -0,0 +1,173 @@
+// Licensed to The Moov Authors under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. The Moov Authors licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package rdfi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/moov-io/achgateway/internal/service"
+	"github.com/moov-io/base/log"
+
+	"github.com/gorilla/mux"
+)
+
+// newTestScheduler builds a PeriodicScheduler with only the fields that
+// don't touch consul leadership or real file processing, which is enough to
+// exercise shard-name validation and the admin HTTP handler end to end.
+// Covering the "ran"/"skipped" branches of tickAll additionally requires
+// fakes for service.Sharding, consul.Client, and a Downloader/Processors
+// pipeline, none of which exist in this snapshot of the repo.
+func newTestScheduler(shardNames []string) *PeriodicScheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &PeriodicScheduler{
+		logger:         log.NewDefaultLogger(),
+		odfi:           &service.ODFIFiles{ShardNames: shardNames},
+		inboundTrigger: make(chan manuallyTriggeredInbound, 1),
+		shutdown:       ctx,
+		shutdownFunc:   cancel,
+	}
+}
+
+func (s *PeriodicScheduler) testRouter() *mux.Router {
+	router := mux.NewRouter()
+	router.HandleFunc("/trigger-inbound", s.triggerInboundHandler)
+	router.HandleFunc("/trigger-inbound/{shardName}", s.triggerInboundHandler)
+	return router
+}
+
+func TestPeriodicScheduler__hasShard(t *testing.T) {
+	s := newTestScheduler([]string{"shard-a", "shard-b"})
+
+	if !s.hasShard("shard-a") {
+		t.Error("expected shard-a to be known")
+	}
+	if s.hasShard("shard-z") {
+		t.Error("expected shard-z to be unknown")
+	}
+}
+
+func TestPeriodicScheduler__tickAllUnknownShard(t *testing.T) {
+	s := newTestScheduler([]string{"shard-a"})
+
+	result, err := s.tickAll("shard-z")
+	if result != nil {
+		t.Errorf("expected no result for an unknown shard, got %#v", result)
+	}
+	if !errors.Is(err, errUnknownShard) {
+		t.Errorf("expected errUnknownShard, got %v", err)
+	}
+}
+
+func TestPeriodicScheduler__tickAllNoShardsConfigured(t *testing.T) {
+	s := newTestScheduler(nil)
+
+	result, err := s.tickAll("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result == nil || len(result.Shards) != 0 {
+		t.Errorf("expected an empty result, got %#v", result)
+	}
+}
+
+func TestPeriodicScheduler__triggerInboundHandlerMethodNotAllowed(t *testing.T) {
+	s := newTestScheduler([]string{"shard-a"})
+	router := s.testRouter()
+
+	req := httptest.NewRequest("GET", "/trigger-inbound", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405, got %d", w.Code)
+	}
+}
+
+func TestPeriodicScheduler__triggerInboundHandlerUnknownShard(t *testing.T) {
+	s := newTestScheduler([]string{"shard-a"})
+	router := s.testRouter()
+
+	go func() {
+		waiter := <-s.inboundTrigger
+		result, err := s.tickAll(waiter.shardName)
+		waiter.C <- manualInboundResponse{Result: result, Err: err}
+	}()
+
+	req := httptest.NewRequest("POST", "/trigger-inbound/shard-z", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown shard, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var body map[string]string
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	if body["error"] == "" {
+		t.Error("expected a non-empty error message")
+	}
+}
+
+func TestPeriodicScheduler__triggerInboundHandlerNoShardsMatches(t *testing.T) {
+	s := newTestScheduler(nil)
+	router := s.testRouter()
+
+	go func() {
+		waiter := <-s.inboundTrigger
+		result, err := s.tickAll(waiter.shardName)
+		waiter.C <- manualInboundResponse{Result: result, Err: err}
+	}()
+
+	req := httptest.NewRequest("POST", "/trigger-inbound", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var result InboundTriggerResult
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	if len(result.Shards) != 0 {
+		t.Errorf("expected no shards to have run, got %#v", result.Shards)
+	}
+}
+
+func TestPeriodicScheduler__triggerInboundHandlerShuttingDown(t *testing.T) {
+	s := newTestScheduler([]string{"shard-a"})
+	s.Shutdown()
+
+	router := s.testRouter()
+
+	req := httptest.NewRequest("POST", "/trigger-inbound", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 once the scheduler is shutting down, got %d", w.Code)
+	}
+}
@@ -20,9 +20,12 @@ package web
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"net/http"
+	"time"
 
 	"github.com/moov-io/ach"
 	"github.com/moov-io/achgateway/internal/incoming"
@@ -36,18 +39,41 @@ import (
 	"gocloud.dev/pubsub"
 )
 
-func NewFilesController(logger log.Logger, cfg service.HTTPConfig, pub stream.Publisher) *FilesController {
-	return &FilesController{
-		logger:    logger,
-		cfg:       cfg,
-		publisher: pub,
+// idempotentReplayHeader is set on responses to submissions and
+// cancellations that were recognized as a retry rather than re-published.
+const idempotentReplayHeader = "X-Idempotent-Replay"
+
+func NewFilesController(logger log.Logger, cfg service.HTTPConfig, pub stream.Publisher) (*FilesController, error) {
+	idempotency, err := newIdempotencyStore(cfg.Idempotency)
+	if err != nil {
+		return nil, fmt.Errorf("files controller: %v", err)
 	}
+	return &FilesController{
+		logger:      logger,
+		cfg:         cfg,
+		publisher:   pub,
+		idempotency: idempotency,
+	}, nil
 }
 
 type FilesController struct {
 	logger    log.Logger
 	cfg       service.HTTPConfig
 	publisher stream.Publisher
+
+	idempotency idempotencyStore
+}
+
+func (c *FilesController) idempotencyTTL() time.Duration {
+	if c.cfg.Idempotency.TTL > 0 {
+		return c.cfg.Idempotency.TTL
+	}
+	return defaultIdempotencyTTL
+}
+
+func hashBody(bs []byte) string {
+	sum := sha256.Sum256(bs)
+	return hex.EncodeToString(sum[:])
 }
 
 func (c *FilesController) AppendRoutes(router *mux.Router) *mux.Router {
@@ -81,11 +107,40 @@ func (c *FilesController) CreateFileHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	key := idempotencyKey(shardKey, fileID)
+	bodyHash := hashBody(bs)
+
+	// reserved tracks whether ReserveCreate already claimed a record for
+	// this attempt, so we know to roll it back if parsing or publishing
+	// fails, and to confirm it once publishing succeeds.
+	var reserved bool
+	if c.idempotency != nil {
+		switch c.idempotency.ReserveCreate(key, bodyHash) {
+		case reserveConflict:
+			w.WriteHeader(http.StatusConflict)
+			return
+		case reservePending:
+			// Another request for this key is still being published; it
+			// hasn't succeeded (or failed) yet, so we can't replay it.
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusAccepted)
+			return
+		case reserveReplay:
+			w.Header().Set(idempotentReplayHeader, "true")
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		reserved = true
+	}
+
 	file, err := ach.NewReader(bytes.NewReader(bs)).Read()
 	if err != nil {
 		// attempt JSON decode
 		f, err := ach.FileFromJSON(bs)
 		if f == nil || err != nil {
+			if reserved {
+				c.idempotency.Forget(key)
+			}
 			w.WriteHeader(http.StatusBadRequest)
 			return
 		}
@@ -93,6 +148,9 @@ func (c *FilesController) CreateFileHandler(w http.ResponseWriter, r *http.Reque
 	}
 
 	if err := c.publishFile(shardKey, fileID, &file); err != nil {
+		if reserved {
+			c.idempotency.Forget(key)
+		}
 		c.logger.With(log.Fields{
 			"shard_key": log.String(shardKey),
 			"file_id":   log.String(fileID),
@@ -102,6 +160,10 @@ func (c *FilesController) CreateFileHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	if reserved {
+		c.idempotency.ConfirmCreate(key, bodyHash, c.idempotencyTTL())
+	}
+
 	w.WriteHeader(http.StatusOK)
 }
 
@@ -150,7 +212,30 @@ func (c *FilesController) CancelFileHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	key := idempotencyKey(shardKey, fileID)
+
+	var reserved bool
+	if c.idempotency != nil {
+		switch c.idempotency.ReserveCancel(key) {
+		case reserveConflict:
+			w.WriteHeader(http.StatusConflict)
+			return
+		case reservePending:
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusAccepted)
+			return
+		case reserveReplay:
+			w.Header().Set(idempotentReplayHeader, "true")
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		reserved = true
+	}
+
 	if err := c.cancelFile(shardKey, fileID); err != nil {
+		if reserved {
+			c.idempotency.Forget(key)
+		}
 		c.logger.With(log.Fields{
 			"shard_key": log.String(shardKey),
 			"file_id":   log.String(fileID),
@@ -160,6 +245,10 @@ func (c *FilesController) CancelFileHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	if reserved {
+		c.idempotency.ConfirmCancel(key, c.idempotencyTTL())
+	}
+
 	w.WriteHeader(http.StatusOK)
 }
 
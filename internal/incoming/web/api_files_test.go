@@ -0,0 +1,213 @@
+// Licensed to The Moov Authors under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. The Moov Authors licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package web
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/moov-io/ach"
+	"github.com/moov-io/achgateway/internal/service"
+	"github.com/moov-io/base/log"
+
+	"github.com/gorilla/mux"
+	"gocloud.dev/pubsub"
+)
+
+type countingPublisher struct {
+	mu    sync.Mutex
+	sends int
+}
+
+func (p *countingPublisher) Send(ctx context.Context, msg *pubsub.Message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.sends++
+	return nil
+}
+
+func (p *countingPublisher) Shutdown(ctx context.Context) error {
+	return nil
+}
+
+func (p *countingPublisher) Count() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.sends
+}
+
+func newTestController(t *testing.T, pub *countingPublisher) (*FilesController, *mux.Router) {
+	t.Helper()
+
+	cfg := service.HTTPConfig{
+		Idempotency: service.IdempotencyConfig{
+			Enabled: true,
+		},
+	}
+	controller, err := NewFilesController(log.NewDefaultLogger(), cfg, pub)
+	if err != nil {
+		t.Fatalf("creating FilesController: %v", err)
+	}
+	router := controller.AppendRoutes(mux.NewRouter())
+	return controller, router
+}
+
+// achFixture builds a minimal, valid PPD credit file so it survives
+// ach.NewReader(...).Read() inside CreateFileHandler. amountCents varies the
+// body (and therefore its idempotency hash) between fixtures.
+func achFixture(t *testing.T, amountCents int) []byte {
+	t.Helper()
+
+	bh := ach.NewBatchHeader()
+	bh.ServiceClassCode = ach.CreditsOnly
+	bh.CompanyName = "Our Company"
+	bh.CompanyIdentification = "123456789"
+	bh.StandardEntryClassCode = ach.PPD
+	bh.CompanyEntryDescription = "PAYROLL"
+	bh.ODFIIdentification = "12345678"
+
+	batch, err := ach.NewBatch(bh)
+	if err != nil {
+		t.Fatalf("creating batch: %v", err)
+	}
+
+	entry := ach.NewEntryDetail()
+	entry.TransactionCode = ach.CheckingCredit
+	entry.RDFIIdentification = "87654321"
+	entry.CheckDigit = "2"
+	entry.DFIAccountNumber = "123456789"
+	entry.Amount = amountCents
+	entry.IndividualName = "Jane Doe"
+	entry.SetTraceNumber(bh.ODFIIdentification, 1)
+	batch.AddEntry(entry)
+
+	if err := batch.Create(); err != nil {
+		t.Fatalf("building batch: %v", err)
+	}
+
+	file := ach.NewFile()
+	file.Header.ImmediateOrigin = "123456789"
+	file.Header.ImmediateOriginName = "Our Company"
+	file.Header.ImmediateDestination = "987654321"
+	file.Header.ImmediateDestinationName = "Their Bank"
+	file.Header.FileCreationDate = "230101"
+	file.Header.FileCreationTime = "0000"
+	file.AddBatch(batch)
+
+	if err := file.Create(); err != nil {
+		t.Fatalf("building file: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := ach.NewWriter(&buf).Write(file); err != nil {
+		t.Fatalf("writing file: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func postFile(router *mux.Router, body []byte) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("POST", "/shards/s1/files/f1", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func deleteFile(router *mux.Router) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("DELETE", "/shards/s1/files/f1", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestFilesController__idempotentReplay(t *testing.T) {
+	pub := &countingPublisher{}
+	_, router := newTestController(t, pub)
+
+	body := achFixture(t, 1000)
+
+	w1 := postFile(router, body)
+	if w1.Code != http.StatusOK {
+		t.Fatalf("unexpected status on first submission: %d", w1.Code)
+	}
+	if w1.Header().Get(idempotentReplayHeader) != "" {
+		t.Error("expected no replay header on first submission")
+	}
+
+	w2 := postFile(router, body)
+	if w2.Code != http.StatusOK {
+		t.Fatalf("unexpected status on replayed submission: %d", w2.Code)
+	}
+	if w2.Header().Get(idempotentReplayHeader) != "true" {
+		t.Error("expected replay header on duplicate submission")
+	}
+
+	if got := pub.Count(); got != 1 {
+		t.Errorf("expected exactly one publish, got %d", got)
+	}
+}
+
+func TestFilesController__idempotentConflict(t *testing.T) {
+	pub := &countingPublisher{}
+	_, router := newTestController(t, pub)
+
+	if w := postFile(router, achFixture(t, 1000)); w.Code != http.StatusOK {
+		t.Fatalf("unexpected status on first submission: %d", w.Code)
+	}
+
+	w := postFile(router, achFixture(t, 2000))
+	if w.Code != http.StatusConflict {
+		t.Errorf("expected 409 for conflicting body, got %d", w.Code)
+	}
+	if got := pub.Count(); got != 1 {
+		t.Errorf("expected conflicting submission to not be published, got %d sends", got)
+	}
+}
+
+func TestFilesController__cancelTombstone(t *testing.T) {
+	pub := &countingPublisher{}
+	_, router := newTestController(t, pub)
+
+	body := achFixture(t, 1000)
+
+	if w := postFile(router, body); w.Code != http.StatusOK {
+		t.Fatalf("unexpected status on submission: %d", w.Code)
+	}
+
+	if w := deleteFile(router); w.Code != http.StatusOK {
+		t.Fatalf("unexpected status on cancel: %d", w.Code)
+	}
+
+	// Replaying the cancellation should be recognized without republishing.
+	w := deleteFile(router)
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected status on replayed cancel: %d", w.Code)
+	}
+	if w.Header().Get(idempotentReplayHeader) != "true" {
+		t.Error("expected replay header on duplicate cancellation")
+	}
+
+	// A late create for the tombstoned ID should be rejected.
+	w = postFile(router, body)
+	if w.Code != http.StatusConflict {
+		t.Errorf("expected 409 for create after cancel, got %d", w.Code)
+	}
+}
@@ -0,0 +1,233 @@
+// Licensed to The Moov Authors under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. The Moov Authors licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package web
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/moov-io/achgateway/internal/service"
+)
+
+// defaultIdempotencyTTL is used when service.IdempotencyConfig.TTL is unset.
+const defaultIdempotencyTTL = 24 * time.Hour
+
+type idempotencyStatus int
+
+const (
+	// idempotencyStatusPending marks a key as claimed by an in-flight
+	// request whose publish/cancel hasn't completed yet. It is never a
+	// terminal state -- ConfirmCreate/ConfirmCancel/Forget always move a
+	// key off of it.
+	idempotencyStatusPending idempotencyStatus = iota
+	idempotencyStatusCreated
+	idempotencyStatusCanceled
+)
+
+// reserveOutcome is returned by ReserveCreate/ReserveCancel to tell the
+// caller whether it may proceed with the side-effecting work (publishing to
+// the stream) or must respond based on a prior or in-flight attempt instead.
+type reserveOutcome int
+
+const (
+	// reserveProceed means the key was unclaimed (or already confirmed
+	// compatible with this attempt); the store now holds a pending
+	// reservation and the caller must do the side-effecting work, then
+	// call ConfirmCreate/ConfirmCancel on success or Forget on failure.
+	reserveProceed reserveOutcome = iota
+	// reservePending means another request for this key is still
+	// in-flight. The caller must not repeat the side-effecting work, and
+	// must not report success since it hasn't happened yet.
+	reservePending
+	// reserveReplay means an identical attempt already completed
+	// successfully; the caller must not repeat the side-effecting work.
+	reserveReplay
+	// reserveConflict means a prior attempt for the same key is
+	// incompatible with this one (different body, or already canceled).
+	reserveConflict
+)
+
+// idempotencyStore deduplicates file submissions and cancellations keyed by
+// shardKey+fileID. ReserveCreate/ReserveCancel atomically claim a key before
+// any side-effecting work happens, so concurrent or fast-retried requests
+// for the same key can't both slip past the check; the key is only marked
+// terminally created/canceled once ConfirmCreate/ConfirmCancel runs after
+// that work actually succeeds. Implementations may be in-memory (the
+// default) or backed by a shared store such as Redis when achgateway runs
+// with multiple replicas and service.IdempotencyConfig.Redis is configured.
+type idempotencyStore interface {
+	// ReserveCreate atomically reconciles a submission against whatever is
+	// already stored for key.
+	ReserveCreate(key, bodyHash string) reserveOutcome
+	// ConfirmCreate marks key as created, valid until ttl elapses. Only
+	// called after ReserveCreate returned reserveProceed and the
+	// subsequent publish succeeded.
+	ConfirmCreate(key, bodyHash string, ttl time.Duration)
+
+	// ReserveCancel atomically reconciles a cancellation against whatever
+	// is already stored for key. Canceling is always allowed, even over a
+	// create -- it just isn't repeated once confirmed -- so reserveConflict
+	// is only returned when the store itself can't be consulted.
+	ReserveCancel(key string) reserveOutcome
+	// ConfirmCancel marks key as canceled (a tombstone), valid until ttl
+	// elapses. Only called after ReserveCancel returned reserveProceed and
+	// the subsequent cancellation succeeded.
+	ConfirmCancel(key string, ttl time.Duration)
+
+	// Forget rolls back a reserveProceed whose side-effecting work ended
+	// up failing. A key with no prior confirmed state is removed
+	// entirely; a key that was previously confirmed (e.g. canceling an
+	// already-created file) reverts to that prior state.
+	Forget(key string)
+}
+
+func idempotencyKey(shardKey, fileID string) string {
+	return shardKey + "/" + fileID
+}
+
+// newIdempotencyStore returns the idempotencyStore configured by cfg, or nil
+// when idempotency checking is disabled.
+func newIdempotencyStore(cfg service.IdempotencyConfig) (idempotencyStore, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	if cfg.Redis != nil {
+		if cfg.Redis.Address == "" {
+			return nil, errors.New("idempotency: Redis.Address is required when Redis is configured")
+		}
+		return newRedisIdempotencyStore(cfg.Redis), nil
+	}
+	return newMemoryIdempotencyStore(), nil
+}
+
+// idempotencyRecord is what's stored in-memory for a (shardKey, fileID)
+// pair so that retried submissions and cancellations can be recognized.
+type idempotencyRecord struct {
+	status   idempotencyStatus
+	bodyHash string
+	expires  time.Time
+}
+
+type memoryIdempotencyStore struct {
+	mu      sync.Mutex
+	records map[string]idempotencyRecord
+}
+
+func newMemoryIdempotencyStore() *memoryIdempotencyStore {
+	return &memoryIdempotencyStore{
+		records: make(map[string]idempotencyRecord),
+	}
+}
+
+func (s *memoryIdempotencyStore) ReserveCreate(key, bodyHash string) reserveOutcome {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec, ok := s.lookupLocked(key)
+	if !ok {
+		// bodyHash is recorded by ConfirmCreate; leaving it empty here lets
+		// Forget tell this reservation apart from a cancel over a created key.
+		s.records[key] = idempotencyRecord{status: idempotencyStatusPending}
+		return reserveProceed
+	}
+	switch rec.status {
+	case idempotencyStatusPending:
+		return reservePending
+	case idempotencyStatusCanceled:
+		return reserveConflict
+	default: // idempotencyStatusCreated
+		if rec.bodyHash != bodyHash {
+			return reserveConflict
+		}
+		return reserveReplay
+	}
+}
+
+func (s *memoryIdempotencyStore) ConfirmCreate(key, bodyHash string, ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[key] = idempotencyRecord{
+		status:   idempotencyStatusCreated,
+		bodyHash: bodyHash,
+		expires:  time.Now().Add(ttl),
+	}
+}
+
+func (s *memoryIdempotencyStore) ReserveCancel(key string) reserveOutcome {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec, ok := s.lookupLocked(key)
+	if !ok {
+		s.records[key] = idempotencyRecord{status: idempotencyStatusPending}
+		return reserveProceed
+	}
+	switch rec.status {
+	case idempotencyStatusPending:
+		return reservePending
+	case idempotencyStatusCanceled:
+		return reserveReplay
+	default: // idempotencyStatusCreated -- claim it for cancellation, remembering
+		// the prior state so Forget can restore it if the cancel fails.
+		rec.status = idempotencyStatusPending
+		s.records[key] = rec
+		return reserveProceed
+	}
+}
+
+func (s *memoryIdempotencyStore) ConfirmCancel(key string, ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[key] = idempotencyRecord{
+		status:  idempotencyStatusCanceled,
+		expires: time.Now().Add(ttl),
+	}
+}
+
+func (s *memoryIdempotencyStore) Forget(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec, ok := s.records[key]
+	if !ok {
+		return
+	}
+	if rec.bodyHash == "" && rec.status == idempotencyStatusPending {
+		// Nothing was confirmed before this reservation (a plain create,
+		// or a cancel with no prior created record) -- forget it entirely.
+		delete(s.records, key)
+		return
+	}
+	// A create existed before a cancel attempt claimed this key; restore it.
+	rec.status = idempotencyStatusCreated
+	s.records[key] = rec
+}
+
+// lookupLocked must be called with s.mu held.
+func (s *memoryIdempotencyStore) lookupLocked(key string) (idempotencyRecord, bool) {
+	rec, ok := s.records[key]
+	if !ok {
+		return idempotencyRecord{}, false
+	}
+	if rec.status != idempotencyStatusPending && time.Now().After(rec.expires) {
+		delete(s.records, key)
+		return idempotencyRecord{}, false
+	}
+	return rec, true
+}
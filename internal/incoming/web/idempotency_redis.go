@@ -0,0 +1,304 @@
+// Licensed to The Moov Authors under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. The Moov Authors licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package web
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/moov-io/achgateway/internal/service"
+)
+
+// redisIdempotencyStore is an idempotencyStore backed by Redis (or anything
+// else that speaks the Redis protocol), so that multiple achgateway
+// replicas agree on what's already been seen. It talks RESP directly over a
+// net.Conn rather than pulling in a client library, and leans on Redis'
+// EVAL to keep each reserve/confirm/forget atomic -- the same guarantee
+// memoryIdempotencyStore gets from holding its mutex across the operation.
+//
+// Keys are stored as "<status>|<bodyHash>" under the redisKey prefix; status
+// is one of "pending", "created", or "canceled".
+type redisIdempotencyStore struct {
+	addr     string
+	password string
+
+	mu   sync.Mutex
+	conn net.Conn
+	rw   *bufio.ReadWriter
+}
+
+func newRedisIdempotencyStore(cfg *service.IdempotencyRedisConfig) *redisIdempotencyStore {
+	return &redisIdempotencyStore{
+		addr:     cfg.Address,
+		password: cfg.Password,
+	}
+}
+
+const redisKeyPrefix = "achgateway:idempotency:"
+
+func redisKey(key string) string {
+	return redisKeyPrefix + key
+}
+
+// reserveCreateScript atomically reconciles a submission against whatever is
+// stored for KEYS[1], claiming it (as "pending") when nothing conflicts.
+const reserveCreateScript = `
+local v = redis.call('GET', KEYS[1])
+if not v then
+  redis.call('SET', KEYS[1], 'pending|')
+  return 'proceed'
+end
+local sep = string.find(v, '|')
+local status = string.sub(v, 1, sep - 1)
+if status == 'pending' then
+  return 'pending'
+end
+if status == 'canceled' then
+  return 'conflict'
+end
+if string.sub(v, sep + 1) == ARGV[1] then
+  return 'replay'
+end
+return 'conflict'
+`
+
+// reserveCancelScript atomically reconciles a cancellation against whatever
+// is stored for KEYS[1]. A prior "created" record is claimed for
+// cancellation (its bodyHash is kept so Forget can restore it on failure),
+// rather than overwritten outright.
+const reserveCancelScript = `
+local v = redis.call('GET', KEYS[1])
+if not v then
+  redis.call('SET', KEYS[1], 'pending|')
+  return 'proceed'
+end
+local sep = string.find(v, '|')
+local status = string.sub(v, 1, sep - 1)
+if status == 'pending' then
+  return 'pending'
+end
+if status == 'canceled' then
+  return 'replay'
+end
+redis.call('SET', KEYS[1], 'pending|' .. string.sub(v, sep + 1))
+return 'proceed'
+`
+
+// forgetScript rolls back a reservation that never got confirmed. A key with
+// no bodyHash behind it (a plain create, or a cancel with no prior created
+// record) is deleted outright; one with a bodyHash is restored to "created".
+const forgetScript = `
+local v = redis.call('GET', KEYS[1])
+if not v then
+  return 0
+end
+local sep = string.find(v, '|')
+local hash = string.sub(v, sep + 1)
+if hash == '' then
+  redis.call('DEL', KEYS[1])
+else
+  redis.call('SET', KEYS[1], 'created|' .. hash)
+end
+return 1
+`
+
+func (s *redisIdempotencyStore) ReserveCreate(key, bodyHash string) reserveOutcome {
+	reply, err := s.eval(reserveCreateScript, redisKey(key), bodyHash)
+	if err != nil {
+		// A store we can't reach must fail closed: treat it as a conflict
+		// rather than risk publishing the same file twice.
+		return reserveConflict
+	}
+	return parseReserveOutcome(reply)
+}
+
+func (s *redisIdempotencyStore) ConfirmCreate(key, bodyHash string, ttl time.Duration) {
+	s.do("SET", redisKey(key), "created|"+bodyHash, "EX", strconv.Itoa(int(ttl.Seconds())))
+}
+
+func (s *redisIdempotencyStore) ReserveCancel(key string) reserveOutcome {
+	reply, err := s.eval(reserveCancelScript, redisKey(key))
+	if err != nil {
+		return reserveConflict
+	}
+	return parseReserveOutcome(reply)
+}
+
+func (s *redisIdempotencyStore) ConfirmCancel(key string, ttl time.Duration) {
+	s.do("SET", redisKey(key), "canceled|", "EX", strconv.Itoa(int(ttl.Seconds())))
+}
+
+func (s *redisIdempotencyStore) Forget(key string) {
+	s.eval(forgetScript, redisKey(key))
+}
+
+func parseReserveOutcome(reply interface{}) reserveOutcome {
+	switch reply {
+	case "proceed":
+		return reserveProceed
+	case "pending":
+		return reservePending
+	case "replay":
+		return reserveReplay
+	default:
+		return reserveConflict
+	}
+}
+
+func (s *redisIdempotencyStore) eval(script string, keyAndArgs ...string) (interface{}, error) {
+	args := append([]string{"EVAL", script, "1"}, keyAndArgs...)
+	return s.do(args...)
+}
+
+func (s *redisIdempotencyStore) do(args ...string) (interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.connectLocked(); err != nil {
+		return nil, err
+	}
+	if err := writeRESPCommand(s.rw.Writer, args); err != nil {
+		s.resetLocked()
+		return nil, err
+	}
+	if err := s.rw.Writer.Flush(); err != nil {
+		s.resetLocked()
+		return nil, err
+	}
+	reply, err := readRESPReply(s.rw.Reader)
+	if err != nil {
+		s.resetLocked()
+		return nil, err
+	}
+	if errReply, ok := reply.(respError); ok {
+		return nil, errors.New(string(errReply))
+	}
+	return reply, nil
+}
+
+// connectLocked must be called with s.mu held.
+func (s *redisIdempotencyStore) connectLocked() error {
+	if s.conn != nil {
+		return nil
+	}
+	conn, err := net.DialTimeout("tcp", s.addr, 5*time.Second)
+	if err != nil {
+		return fmt.Errorf("redis: dial %s: %v", s.addr, err)
+	}
+	s.conn = conn
+	s.rw = bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn))
+
+	if s.password != "" {
+		if err := writeRESPCommand(s.rw.Writer, []string{"AUTH", s.password}); err != nil {
+			s.resetLocked()
+			return err
+		}
+		if err := s.rw.Writer.Flush(); err != nil {
+			s.resetLocked()
+			return err
+		}
+		if _, err := readRESPReply(s.rw.Reader); err != nil {
+			s.resetLocked()
+			return fmt.Errorf("redis: auth: %v", err)
+		}
+	}
+	return nil
+}
+
+// resetLocked must be called with s.mu held.
+func (s *redisIdempotencyStore) resetLocked() {
+	if s.conn != nil {
+		s.conn.Close()
+	}
+	s.conn, s.rw = nil, nil
+}
+
+// respError distinguishes a RESP error reply ("-ERR ...") from an ordinary
+// string reply so callers can tell a command failure from a value of "ERR".
+type respError string
+
+func writeRESPCommand(w *bufio.Writer, args []string) error {
+	if _, err := fmt.Fprintf(w, "*%d\r\n", len(args)); err != nil {
+		return err
+	}
+	for _, arg := range args {
+		if _, err := fmt.Fprintf(w, "$%d\r\n%s\r\n", len(arg), arg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readRESPReply(r *bufio.Reader) (interface{}, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimRight(line, "\r\n")
+	if line == "" {
+		return nil, errors.New("redis: empty reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return respError(line[1:]), nil
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		buf := make([]byte, n+2) // payload plus trailing CRLF
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		return string(buf[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		arr := make([]interface{}, n)
+		for i := 0; i < n; i++ {
+			v, err := readRESPReply(r)
+			if err != nil {
+				return nil, err
+			}
+			arr[i] = v
+		}
+		return arr, nil
+	default:
+		return nil, fmt.Errorf("redis: unexpected reply prefix %q", line[0])
+	}
+}
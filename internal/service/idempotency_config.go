@@ -0,0 +1,43 @@
+// Licensed to The Moov Authors under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. The Moov Authors licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package service
+
+import "time"
+
+// IdempotencyConfig configures the cache FilesController uses to recognize
+// retried file submissions and cancellations for the same (shardKey, fileID)
+// pair so they aren't re-published to the stream.
+type IdempotencyConfig struct {
+	Enabled bool
+
+	// TTL controls how long a submission or cancellation is remembered for.
+	// Defaults to 24h when unset.
+	TTL time.Duration
+
+	// Redis, when set, backs the idempotency cache with a shared Redis
+	// instance so multiple achgateway replicas agree on what's already been
+	// seen. When nil an in-memory cache scoped to this process is used.
+	Redis *IdempotencyRedisConfig
+}
+
+// IdempotencyRedisConfig holds connection details for a Redis-backed
+// idempotency cache.
+type IdempotencyRedisConfig struct {
+	Address  string
+	Password string
+}
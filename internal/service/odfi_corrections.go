@@ -0,0 +1,56 @@
+// Licensed to The Moov Authors under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. The Moov Authors licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package service
+
+import "strings"
+
+// ODFICorrections configures the processor which looks for and parses
+// COR/NOC (Notification of Change) files returned by an RDFI.
+type ODFICorrections struct {
+	Enabled     bool
+	PathMatcher string
+
+	// ChangeCodeAllow, when non-empty, restricts processing to only the
+	// listed NACHA change codes (C01-C14). ChangeCodeDeny removes codes
+	// from consideration even if ChangeCodeAllow would otherwise permit
+	// them. An empty ChangeCodeAllow means every code is processed,
+	// subject to ChangeCodeDeny.
+	ChangeCodeAllow []string
+	ChangeCodeDeny  []string
+}
+
+// Allowed reports whether a NACHA change code (e.g. "C01") should be
+// processed according to the configured allow/deny lists.
+func (cfg ODFICorrections) Allowed(code string) bool {
+	if containsChangeCode(cfg.ChangeCodeDeny, code) {
+		return false
+	}
+	if len(cfg.ChangeCodeAllow) == 0 {
+		return true
+	}
+	return containsChangeCode(cfg.ChangeCodeAllow, code)
+}
+
+func containsChangeCode(codes []string, code string) bool {
+	for i := range codes {
+		if strings.EqualFold(codes[i], code) {
+			return true
+		}
+	}
+	return false
+}
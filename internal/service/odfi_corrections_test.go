@@ -0,0 +1,40 @@
+// Licensed to The Moov Authors under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. The Moov Authors licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package service
+
+import "testing"
+
+func TestODFICorrections__Allowed(t *testing.T) {
+	cfg := ODFICorrections{}
+	if !cfg.Allowed("C01") {
+		t.Error("expected all codes allowed when no allow/deny list is configured")
+	}
+
+	cfg.ChangeCodeAllow = []string{"C01", "C02"}
+	if !cfg.Allowed("c01") {
+		t.Error("expected allow list to match case-insensitively")
+	}
+	if cfg.Allowed("C05") {
+		t.Error("expected code outside allow list to be rejected")
+	}
+
+	cfg.ChangeCodeDeny = []string{"C01"}
+	if cfg.Allowed("C01") {
+		t.Error("expected deny list to take precedence over allow list")
+	}
+}